@@ -0,0 +1,350 @@
+// Package apk implements nfpm.Packager providing apk bindings.
+package apk
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1" // nolint:gosec
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/goreleaser/nfpm"
+	"github.com/goreleaser/nfpm/internal/manifest"
+	"github.com/goreleaser/nfpm/internal/sign"
+)
+
+// nolint: gochecknoinits
+func init() {
+	nfpm.Register("apk", Default)
+}
+
+// Default apk packager.
+// nolint: gochecknoglobals
+var Default = &Apk{}
+
+// Apk is a nfpm.Packager implementation that creates alpine packages.
+type Apk struct{}
+
+// ConventionalFileName returns a file name according to the conventions for
+// apk packages.
+func (*Apk) ConventionalFileName(info *nfpm.Info) string {
+	return fmt.Sprintf("%s_%s_%s.apk", info.Name, info.Version, info.Arch)
+}
+
+// Manifest returns the files, directories and symlinks that Package would
+// write for info, without building a package.
+func (*Apk) Manifest(info *nfpm.Info) ([]nfpm.FileEntry, error) {
+	info = nfpm.WithDefaults(info)
+	return manifest.Walk(info.Files, info.ConfigFiles, info.EmptyFolders)
+}
+
+// Package writes an apk package to w.
+func (*Apk) Package(info *nfpm.Info, w io.Writer) error {
+	info = nfpm.WithDefaults(info)
+	info.Arch = toAlpineArch(info.Arch)
+
+	var size int64
+	var dataTarGz bytes.Buffer
+	var controlTarGz bytes.Buffer
+
+	var dataDigest = sha1.New() // nolint:gosec
+	dataBuilder := createBuilderData(info, &size)
+	if err := writeTarGz(&dataTarGz, dataBuilder); err != nil {
+		return err
+	}
+	if _, err := io.Copy(dataDigest, bytes.NewReader(dataTarGz.Bytes())); err != nil {
+		return err
+	}
+
+	controlBuilder := createBuilderControl(info, size, dataDigest.Sum(nil))
+	if err := writeTarGz(&controlTarGz, controlBuilder); err != nil {
+		return err
+	}
+
+	if info.APK.Signature.KeyFile != "" {
+		var signatureTarGz bytes.Buffer
+		if err := createSignature(&signatureTarGz, info, dataDigest.Sum(nil)); err != nil {
+			return err
+		}
+		if err := combineToApk(w, &dataTarGz, io.MultiReader(&signatureTarGz, &controlTarGz)); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	return combineToApk(w, &dataTarGz, &controlTarGz)
+}
+
+func writeTarGz(w io.Writer, fn func(tw *tar.Writer) error) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	if err := fn(tw); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// combineToApk concatenates the control and data gzip streams, in that
+// order, into an apk file: apk is just a concatenation of gzip members.
+func combineToApk(target io.Writer, data, control io.Reader) error {
+	if _, err := io.Copy(target, control); err != nil {
+		return err
+	}
+	if _, err := io.Copy(target, data); err != nil {
+		return err
+	}
+	return nil
+}
+
+// createBuilderData creates the func that writes the data.tar.gz contents
+// (the actual payload) for the given info, also updating size with the
+// installed size of the package.
+func createBuilderData(info *nfpm.Info, size *int64) func(tw *tar.Writer) error {
+	return func(tw *tar.Writer) error {
+		entries, err := manifest.Walk(info.Files, info.ConfigFiles, info.EmptyFolders)
+		if err != nil {
+			return err
+		}
+
+		created := map[string]bool{}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				if err := createDirs(tw, created, entry.Path); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := createDirs(tw, created, path.Dir(entry.Path)); err != nil {
+				return err
+			}
+			if err := writeFileEntry(tw, entry, size); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func createDirs(tw *tar.Writer, created map[string]bool, dir string) error {
+	for _, part := range pathsToCreate(dir) {
+		if created[part] {
+			continue
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     part + "/",
+			Mode:     0755,
+			Typeflag: tar.TypeDir,
+			ModTime:  time.Now(),
+		}); err != nil {
+			return fmt.Errorf("failed to create folder %s: %w", part, err)
+		}
+		created[part] = true
+	}
+	if created[strings.TrimPrefix(dir, "/")] {
+		return nil
+	}
+	created[strings.TrimPrefix(dir, "/")] = true
+	return tw.WriteHeader(&tar.Header{
+		Name:     strings.TrimPrefix(dir, "/") + "/",
+		Mode:     0755,
+		Typeflag: tar.TypeDir,
+		ModTime:  time.Now(),
+	})
+}
+
+func writeFileEntry(tw *tar.Writer, entry manifest.FileEntry, size *int64) error {
+	*size += entry.Size
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    strings.TrimPrefix(entry.Path, "/"),
+		Size:    entry.Size,
+		Mode:    int64(entry.Mode),
+		ModTime: entry.ModTime,
+	}); err != nil {
+		return fmt.Errorf("failed to write header for %s: %w", entry.Path, err)
+	}
+
+	file, err := os.Open(entry.Source) // nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", entry.Source, err)
+	}
+	defer file.Close() // nolint:errcheck
+
+	if _, err := io.Copy(tw, file); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", entry.Source, err)
+	}
+	return nil
+}
+
+// pathsToCreate returns the list of directories (without leading slash)
+// that need to exist before the given path can be created.
+func pathsToCreate(p string) []string {
+	var paths []string
+	cur := path.Dir(strings.TrimPrefix(p, "/"))
+	if cur == "." {
+		return nil
+	}
+	for cur != "." && cur != "/" {
+		paths = append([]string{cur}, paths...)
+		cur = path.Dir(cur)
+	}
+	return paths
+}
+
+type controlData struct {
+	Info          *nfpm.Info
+	InstalledSize int64
+}
+
+// nolint: gochecknoglobals
+var controlTemplate = template.Must(template.New("control").Parse(`# Generated by nFPM
+pkgname = {{.Info.Name}}
+pkgver = {{.Info.Version}}{{if .Info.Release}}-{{.Info.Release}}{{else}}-r0{{end}}
+pkgdesc = {{.Info.Description}}
+url = {{.Info.Homepage}}
+builddate = {{.BuildDate}}
+packager = {{.Info.Maintainer}}
+size = {{.InstalledSize}}
+arch = {{.Info.Arch}}
+{{- range .Info.Depends}}
+depend = {{.}}
+{{- end}}
+{{- range .Info.Provides}}
+provides = {{.}}
+{{- end}}
+{{- range .Info.Replaces}}
+replaces = {{.}}
+{{- end}}
+`))
+
+type controlTemplateData struct {
+	controlData
+	BuildDate string
+}
+
+func writeControl(w io.Writer, data controlData) error {
+	return controlTemplate.Execute(w, controlTemplateData{
+		controlData: data,
+		BuildDate:   "0",
+	})
+}
+
+func createBuilderControl(info *nfpm.Info, size int64, digest []byte) func(tw *tar.Writer) error {
+	return func(tw *tar.Writer) error {
+		var buf bytes.Buffer
+		if err := writeControl(&buf, controlData{Info: info, InstalledSize: size}); err != nil {
+			return err
+		}
+		if err := newFileInsideTarGz(tw, buf.Bytes(), ".PKGINFO"); err != nil {
+			return err
+		}
+
+		if info.Scripts.PreInstall != "" || info.Scripts.PostInstall != "" ||
+			info.Scripts.PreRemove != "" || info.Scripts.PostRemove != "" {
+			installScript, err := buildInstallScript(info)
+			if err != nil {
+				return err
+			}
+			if err := newFileInsideTarGz(tw, installScript, ".INSTALL"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func buildInstallScript(info *nfpm.Info) ([]byte, error) {
+	var buf bytes.Buffer
+	sections := []struct {
+		fn   string
+		path string
+	}{
+		{"pre-install", info.Scripts.PreInstall},
+		{"post-install", info.Scripts.PostInstall},
+		{"pre-deinstall", info.Scripts.PreRemove},
+		{"post-deinstall", info.Scripts.PostRemove},
+	}
+	for _, s := range sections {
+		if s.path == "" {
+			continue
+		}
+		contents, err := ioutil.ReadFile(s.path) // nolint:gosec
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s script: %w", s.fn, err)
+		}
+		fmt.Fprintf(&buf, "%s() {\n%s\n}\n", s.fn, contents)
+	}
+	return buf.Bytes(), nil
+}
+
+func newFileInsideTarGz(tw *tar.Writer, content []byte, dst string) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    dst,
+		Size:    int64(len(content)),
+		Mode:    0644,
+		ModTime: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("failed to write header for %s: %w", dst, err)
+	}
+	_, err := tw.Write(content)
+	return err
+}
+
+func createSignatureBuilder(digest []byte, info *nfpm.Info) func(tw *tar.Writer) error {
+	return func(tw *tar.Writer) error {
+		signerType := info.APK.Signature.Type
+		if signerType != "" && signerType != "rsa" {
+			return &nfpm.ErrSigningFailure{
+				Err: fmt.Errorf("apk only supports raw RSA signatures (.SIGN.RSA.*), got signature type %q", signerType),
+			}
+		}
+
+		signer, err := sign.Get(signerType)
+		if err != nil {
+			return &nfpm.ErrSigningFailure{Err: err}
+		}
+		signature, err := signer.Sign(digest, sign.SignOpts{
+			KeyFile:    info.APK.Signature.KeyFile,
+			KeyName:    info.APK.Signature.KeyName,
+			Passphrase: info.APK.Signature.KeyPassphrase,
+		})
+		if err != nil {
+			return &nfpm.ErrSigningFailure{Err: err}
+		}
+		return newFileInsideTarGz(tw, signature, fmt.Sprintf(".SIGN.RSA.%s", info.APK.Signature.KeyName))
+	}
+}
+
+func createSignature(w io.Writer, info *nfpm.Info, digest []byte) error {
+	if len(digest) != sha1.Size {
+		return &nfpm.ErrSigningFailure{Err: fmt.Errorf("digest must be a SHA1 digest, got %d bytes", len(digest))}
+	}
+	return writeTarGz(w, createSignatureBuilder(digest, info))
+}
+
+// nolint: gochecknoglobals
+var archToAlpine = map[string]string{
+	"386":   "x86",
+	"amd64": "x86_64",
+	"arm":   "armhf",
+	"arm6":  "armhf",
+	"arm7":  "armhf",
+	"arm64": "aarch64",
+}
+
+func toAlpineArch(arch string) string {
+	if alpineArch, ok := archToAlpine[arch]; ok {
+		return alpineArch
+	}
+	return arch
+}
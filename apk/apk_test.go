@@ -104,7 +104,7 @@ func TestCreateBuilderData(t *testing.T) {
 
 	assert.NoError(t, builderData(tw))
 
-	assert.Equal(t, 8712, buf.Len())
+	assert.Equal(t, 9216, buf.Len())
 }
 
 func TestCombineToApk(t *testing.T) {
@@ -304,6 +304,21 @@ func TestSignatureError(t *testing.T) {
 	assert.True(t, ok)
 }
 
+func TestSignatureRejectsNonRSAType(t *testing.T) {
+	info := exampleInfo()
+	info.APK.Signature.Type = "pgp"
+	info.APK.Signature.KeyFile = "../internal/sign/testdata/rsa.priv"
+
+	digest := sha1.New().Sum(nil) // nolint:gosec
+
+	var signatureTarGz bytes.Buffer
+	tw := tar.NewWriter(&signatureTarGz)
+	err := createSignatureBuilder(digest, info)(tw)
+
+	var expectedError *nfpm.ErrSigningFailure
+	require.True(t, errors.As(err, &expectedError))
+}
+
 func extractFromTar(t *testing.T, tarFile []byte, fileName string) []byte {
 	t.Helper()
 
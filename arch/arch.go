@@ -0,0 +1,272 @@
+// Package arch implements nfpm.Packager providing bindings for Arch Linux
+// (pacman) packages.
+package arch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/goreleaser/nfpm"
+	"github.com/goreleaser/nfpm/internal/manifest"
+)
+
+// nolint: gochecknoinits
+func init() {
+	nfpm.Register("arch", Default)
+}
+
+// Default arch packager.
+// nolint: gochecknoglobals
+var Default = &Arch{}
+
+// Arch is a nfpm.Packager implementation that creates Arch Linux (pacman)
+// packages.
+type Arch struct{}
+
+// ConventionalFileName returns a file name according to pacman's naming
+// convention: name-version-release-arch.pkg.tar.zst.
+func (*Arch) ConventionalFileName(info *nfpm.Info) string {
+	release := info.Release
+	if release == "" {
+		release = "1"
+	}
+	return fmt.Sprintf("%s-%s-%s-%s.pkg.tar.zst", info.Name, info.Version, release, toArchArch(info.Arch))
+}
+
+// Manifest returns the files, directories and symlinks that Package would
+// write for info, without building a package.
+func (*Arch) Manifest(info *nfpm.Info) ([]nfpm.FileEntry, error) {
+	info = nfpm.WithDefaults(info)
+	return manifest.Walk(info.Files, info.ConfigFiles, info.EmptyFolders)
+}
+
+// Package writes a pacman package to w.
+func (*Arch) Package(info *nfpm.Info, w io.Writer) error {
+	info = nfpm.WithDefaults(info)
+	info.Arch = toArchArch(info.Arch)
+
+	entries, err := manifest.Walk(info.Files, info.ConfigFiles, info.EmptyFolders)
+	if err != nil {
+		return err
+	}
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(zw)
+
+	pkginfo, err := buildPkgInfo(info, entries)
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, ".PKGINFO", pkginfo); err != nil {
+		return err
+	}
+
+	if install, err := buildInstallScript(info); err != nil {
+		return err
+	} else if install != nil {
+		if err := writeTarFile(tw, ".INSTALL", install); err != nil {
+			return err
+		}
+	}
+
+	mtree, err := buildMTree(entries)
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, ".MTREE", mtree); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := writeEntry(tw, entry); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeEntry writes a single manifest.FileEntry into the package payload.
+func writeEntry(tw *tar.Writer, e manifest.FileEntry) error {
+	name := strings.TrimPrefix(e.Path, "/")
+	switch {
+	case e.IsDir():
+		return tw.WriteHeader(&tar.Header{
+			Name:     name + "/",
+			Typeflag: tar.TypeDir,
+			Mode:     int64(e.Mode.Perm()),
+			ModTime:  e.ModTime,
+		})
+	case e.IsSymlink():
+		return tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeSymlink,
+			Linkname: e.LinkTarget,
+			ModTime:  e.ModTime,
+		})
+	default:
+		if err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Size:    e.Size,
+			Mode:    int64(e.Mode.Perm()),
+			ModTime: e.ModTime,
+		}); err != nil {
+			return err
+		}
+		f, err := os.Open(e.Source) // nolint:gosec
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint:errcheck
+		_, err = io.Copy(tw, f)
+		return err
+	}
+}
+
+func writeTarFile(tw *tar.Writer, name string, content []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Size:    int64(len(content)),
+		Mode:    0644,
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(content)
+	return err
+}
+
+type pkginfoData struct {
+	Info *nfpm.Info
+	Size int64
+	Now  int64
+}
+
+// nolint: gochecknoglobals
+var pkginfoTemplate = template.Must(template.New("pkginfo").Parse(`pkgname = {{.Info.Name}}
+pkgbase = {{.Info.Name}}
+pkgver = {{.Info.Version}}-{{if .Info.Release}}{{.Info.Release}}{{else}}1{{end}}
+pkgdesc = {{.Info.Description}}
+url = {{.Info.Homepage}}
+builddate = {{.Now}}
+packager = {{.Info.Maintainer}}
+size = {{.Size}}
+arch = {{.Info.Arch}}
+{{- range .Info.Depends}}
+depend = {{.}}
+{{- end}}
+{{- range .Info.OptDepends}}
+optdepend = {{.Name}}{{.Version}}{{if .Reason}}: {{.Reason}}{{end}}
+{{- end}}
+{{- range .Info.Provides}}
+provides = {{.}}
+{{- end}}
+{{- range .Info.Conflicts}}
+conflict = {{.}}
+{{- end}}
+{{- range .Info.Replaces}}
+replaces = {{.}}
+{{- end}}
+`))
+
+func buildPkgInfo(info *nfpm.Info, entries []manifest.FileEntry) ([]byte, error) {
+	var size int64
+	for _, e := range entries {
+		size += e.Size
+	}
+	var buf bytes.Buffer
+	if err := pkginfoTemplate.Execute(&buf, pkginfoData{Info: info, Size: size, Now: 0}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// buildInstallScript synthesizes a .INSTALL scriptlet out of info.Scripts,
+// mapping each hook onto its pacman shell-function equivalent.
+func buildInstallScript(info *nfpm.Info) ([]byte, error) {
+	hooks := []struct {
+		fn   string
+		path string
+	}{
+		{"pre_install", info.Scripts.PreInstall},
+		{"post_install", info.Scripts.PostInstall},
+		{"pre_upgrade", info.Scripts.PreInstall},
+		{"post_upgrade", info.Scripts.PostInstall},
+		{"pre_remove", info.Scripts.PreRemove},
+		{"post_remove", info.Scripts.PostRemove},
+	}
+
+	var buf bytes.Buffer
+	var wrote bool
+	for _, hook := range hooks {
+		if hook.path == "" {
+			continue
+		}
+		contents, err := ioutil.ReadFile(hook.path) // nolint:gosec
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s script: %w", hook.fn, err)
+		}
+		fmt.Fprintf(&buf, "%s() {\n%s\n}\n", hook.fn, contents)
+		wrote = true
+	}
+	if !wrote {
+		return nil, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// buildMTree renders a gzipped MTREE manifest listing every payload entry,
+// which pacman validates installs against.
+func buildMTree(entries []manifest.FileEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+
+	fmt.Fprintln(gw, "#mtree")
+	for _, e := range entries {
+		name := "./" + strings.TrimPrefix(path.Clean(e.Path), "/")
+		if e.IsDir() {
+			fmt.Fprintf(gw, "%s type=dir mode=%o\n", name, e.Mode.Perm())
+			continue
+		}
+		fmt.Fprintf(gw, "%s type=file mode=%o size=%d time=%d.0 sha256digest=%x sha1digest=%x md5digest=%x\n",
+			name, e.Mode.Perm(), e.Size, e.ModTime.Unix(), e.SHA256, e.SHA1, e.MD5)
+	}
+
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// nolint: gochecknoglobals
+var archToArch = map[string]string{
+	"amd64": "x86_64",
+	"arm64": "aarch64",
+	"arm":   "armv7h",
+	"386":   "i686",
+	"any":   "any",
+}
+
+func toArchArch(arch string) string {
+	if pacmanArch, ok := archToArch[arch]; ok {
+		return pacmanArch
+	}
+	return arch
+}
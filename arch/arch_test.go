@@ -0,0 +1,164 @@
+package arch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goreleaser/nfpm"
+	"github.com/goreleaser/nfpm/internal/manifest"
+)
+
+func exampleInfo() *nfpm.Info {
+	return nfpm.WithDefaults(&nfpm.Info{
+		Name:        "foo",
+		Arch:        "amd64",
+		Description: "Foo does things",
+		Maintainer:  "Carlos A Becker <pkg@carlosbecker.com>",
+		Version:     "1.0.0",
+		Release:     "1",
+		Homepage:    "http://carlosbecker.com",
+		Overridables: nfpm.Overridables{
+			Depends: []string{
+				"bash",
+			},
+			Provides: []string{
+				"foo-cli",
+			},
+			OptDepends: []nfpm.OptDependency{
+				{Name: "bash-completion", Reason: "enables tab completion"},
+			},
+			Files: map[string]string{
+				"../testdata/fake": "/usr/local/bin/fake",
+			},
+			ConfigFiles: map[string]string{
+				"../testdata/whatever.conf": "/etc/fake/fake.conf",
+			},
+			EmptyFolders: []string{
+				"/var/log/whatever",
+			},
+		},
+	})
+}
+
+func TestArchToArch(t *testing.T) {
+	for nfpmArch, expected := range map[string]string{
+		"amd64": "x86_64",
+		"arm64": "aarch64",
+		"arm":   "armv7h",
+		"386":   "i686",
+		"any":   "any",
+		"weird": "weird",
+	} {
+		assert.Equal(t, expected, toArchArch(nfpmArch))
+	}
+}
+
+func TestConventionalFileName(t *testing.T) {
+	info := exampleInfo()
+	assert.Equal(t, "foo-1.0.0-1-x86_64.pkg.tar.zst", Default.ConventionalFileName(info))
+}
+
+func TestPackage(t *testing.T) {
+	info := exampleInfo()
+	var buf bytes.Buffer
+	require.NoError(t, Default.Package(info, &buf))
+
+	zr, err := zstd.NewReader(&buf)
+	require.NoError(t, err)
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+	assert.Contains(t, names, ".PKGINFO")
+	assert.Contains(t, names, ".MTREE")
+	assert.Contains(t, names, "usr/local/bin/fake")
+	assert.Contains(t, names, "etc/fake/fake.conf")
+}
+
+func TestPackageWithScripts(t *testing.T) {
+	info := exampleInfo()
+	info.Scripts = nfpm.Scripts{
+		PreInstall:  "../testdata/scripts/preinstall.sh",
+		PostInstall: "../testdata/scripts/postinstall.sh",
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Default.Package(info, &buf))
+
+	zr, err := zstd.NewReader(&buf)
+	require.NoError(t, err)
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+	var foundInstall bool
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if hdr.Name == ".INSTALL" {
+			foundInstall = true
+		}
+	}
+	assert.True(t, foundInstall)
+}
+
+func TestBuildPkgInfo(t *testing.T) {
+	info := exampleInfo()
+	entries, err := manifest.Walk(info.Files, info.ConfigFiles, info.EmptyFolders)
+	require.NoError(t, err)
+
+	control, err := buildPkgInfo(info, entries)
+	require.NoError(t, err)
+	assert.Contains(t, string(control), "pkgname = foo")
+	assert.Contains(t, string(control), "pkgver = 1.0.0-1")
+	assert.Contains(t, string(control), "depend = bash")
+	assert.Contains(t, string(control), "optdepend = bash-completion: enables tab completion")
+	assert.Contains(t, string(control), "provides = foo-cli")
+}
+
+func TestBuildMTree(t *testing.T) {
+	info := exampleInfo()
+	entries, err := manifest.Walk(info.Files, info.ConfigFiles, info.EmptyFolders)
+	require.NoError(t, err)
+
+	mtree, err := buildMTree(entries)
+	require.NoError(t, err)
+
+	gr, err := gzip.NewReader(bytes.NewReader(mtree))
+	require.NoError(t, err)
+	defer gr.Close()
+
+	contents, err := ioutil.ReadAll(gr)
+	require.NoError(t, err)
+	assert.Contains(t, string(contents), "#mtree")
+	assert.Contains(t, string(contents), "usr/local/bin/fake")
+	assert.Contains(t, string(contents), "sha256digest=")
+}
+
+func TestFileDoesNotExist(t *testing.T) {
+	info := exampleInfo()
+	info.Files = map[string]string{
+		"../testdata/doesnotexist": "/usr/local/bin/fake",
+	}
+	var buf bytes.Buffer
+	err := Default.Package(info, &buf)
+	assert.EqualError(t, err, "glob failed: ../testdata/doesnotexist: file does not exist")
+}
@@ -0,0 +1,197 @@
+package arch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5" // nolint:gosec
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// pkgRecord is everything repo-add needs to know about a single built
+// package in order to add it to a repository database.
+type pkgRecord struct {
+	name  string
+	desc  string
+	files []string
+}
+
+// BuildRepo implements nfpm.RepoBuilder, generating a repo-add-style
+// database out of the packages found in pkgPaths: one directory per
+// package containing a "desc" file, and, when withFiles is true, a
+// "files" file listing every payload path.
+func (*Arch) BuildRepo(pkgPaths []string, withFiles bool, w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, pkgPath := range pkgPaths {
+		record, err := readPkgRecord(pkgPath)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pkgPath, err)
+		}
+
+		dir := record.name + "/"
+		if err := tw.WriteHeader(&tar.Header{Name: dir, Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+			return err
+		}
+		if err := writeTarFile(tw, dir+"desc", []byte(record.desc)); err != nil {
+			return err
+		}
+		if withFiles {
+			var buf bytes.Buffer
+			buf.WriteString("%FILES%\n")
+			for _, f := range record.files {
+				fmt.Fprintln(&buf, f)
+			}
+			if err := writeTarFile(tw, dir+"files", buf.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func readPkgRecord(pkgPath string) (*pkgRecord, error) {
+	raw, err := ioutil.ReadFile(pkgPath) // nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zstd.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var pkginfo string
+	var files []string
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Name == ".PKGINFO" {
+			bts, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			pkginfo = string(bts)
+			continue
+		}
+		if !strings.HasPrefix(hdr.Name, ".") {
+			files = append(files, hdr.Name)
+		}
+	}
+	if pkginfo == "" {
+		return nil, fmt.Errorf("package is missing .PKGINFO")
+	}
+
+	md5sum := md5.Sum(raw) // nolint:gosec
+	sha256sum := sha256.Sum256(raw)
+
+	fields := parsePkgInfo(pkginfo)
+	name := fields["pkgname"] + "-" + fields["pkgver"]
+
+	var desc bytes.Buffer
+	writeDescField(&desc, "NAME", fields["pkgname"])
+	writeDescField(&desc, "VERSION", fields["pkgver"])
+	writeDescField(&desc, "DESC", fields["pkgdesc"])
+	writeDescField(&desc, "CSIZE", strconv.Itoa(len(raw)))
+	writeDescField(&desc, "ISIZE", fields["size"])
+	writeDescField(&desc, "MD5SUM", fmt.Sprintf("%x", md5sum))
+	writeDescField(&desc, "SHA256SUM", fmt.Sprintf("%x", sha256sum))
+	writeDescField(&desc, "URL", fields["url"])
+	writeDescField(&desc, "ARCH", fields["arch"])
+	writeDescField(&desc, "BUILDDATE", fields["builddate"])
+	writeDescField(&desc, "PACKAGER", fields["packager"])
+	writeDescListField(&desc, "DEPENDS", listPkgInfoField(pkginfo, "depend"))
+	writeDescListField(&desc, "OPTDEPENDS", listPkgInfoField(pkginfo, "optdepend"))
+	writeDescListField(&desc, "PROVIDES", listPkgInfoField(pkginfo, "provides"))
+	writeDescListField(&desc, "CONFLICTS", listPkgInfoField(pkginfo, "conflict"))
+	writeDescListField(&desc, "REPLACES", listPkgInfoField(pkginfo, "replaces"))
+
+	if sig, err := readPkgSignature(pkgPath); err == nil {
+		writeDescField(&desc, "PGPSIG", base64.StdEncoding.EncodeToString(sig))
+	}
+
+	return &pkgRecord{name: name, desc: desc.String(), files: files}, nil
+}
+
+// readPkgSignature reads the detached OpenPGP signature written alongside
+// pkgPath by the arch signer (pkgPath + ".sig"), the same layout pacman's
+// own repo-add expects. It's optional: unsigned packages simply don't get
+// a PGPSIG field in their desc record.
+func readPkgSignature(pkgPath string) ([]byte, error) {
+	return ioutil.ReadFile(pkgPath + ".sig") // nolint:gosec
+}
+
+// parsePkgInfo extracts the single-valued "key = value" fields of a
+// .PKGINFO file into a map, keeping the first occurrence of each key.
+func parsePkgInfo(pkginfo string) map[string]string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(pkginfo, "\n") {
+		key, value, ok := splitPkgInfoLine(line)
+		if !ok {
+			continue
+		}
+		if _, exists := fields[key]; !exists {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// listPkgInfoField returns every value of a repeated "key = value" field,
+// such as "depend" or "provides", in file order.
+func listPkgInfoField(pkginfo, key string) []string {
+	var values []string
+	for _, line := range strings.Split(pkginfo, "\n") {
+		k, v, ok := splitPkgInfoLine(line)
+		if ok && k == key {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func splitPkgInfoLine(line string) (key, value string, ok bool) {
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+func writeDescField(w io.Writer, key, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(w, "%%%s%%\n%s\n\n", key, value)
+}
+
+func writeDescListField(w io.Writer, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%%%s%%\n", key)
+	for _, v := range values {
+		fmt.Fprintln(w, v)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,99 @@
+package arch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildTestPackage(t *testing.T, dir string) string {
+	t.Helper()
+	info := exampleInfo()
+
+	pkgPath := filepath.Join(dir, Default.ConventionalFileName(info))
+	f, err := os.Create(pkgPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.NoError(t, Default.Package(info, f))
+	return pkgPath
+}
+
+func TestBuildRepo(t *testing.T) {
+	dir := t.TempDir()
+	pkgPath := buildTestPackage(t, dir)
+
+	var db bytes.Buffer
+	require.NoError(t, Default.BuildRepo([]string{pkgPath}, true, &db))
+
+	gr, err := gzip.NewReader(&db)
+	require.NoError(t, err)
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	var descFound, filesFound bool
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		switch filepath.Base(hdr.Name) {
+		case "desc":
+			bts, err := ioutil.ReadAll(tr)
+			require.NoError(t, err)
+			assert.Contains(t, string(bts), "%NAME%\nfoo\n")
+			assert.Contains(t, string(bts), "%DEPENDS%\nbash\n")
+			assert.Contains(t, string(bts), "%OPTDEPENDS%\nbash-completion: enables tab completion\n")
+			descFound = true
+		case "files":
+			bts, err := ioutil.ReadAll(tr)
+			require.NoError(t, err)
+			assert.Contains(t, string(bts), "usr/local/bin/fake")
+			filesFound = true
+		}
+	}
+	assert.True(t, descFound)
+	assert.True(t, filesFound)
+}
+
+func TestBuildRepoWithSignature(t *testing.T) {
+	dir := t.TempDir()
+	pkgPath := buildTestPackage(t, dir)
+	require.NoError(t, ioutil.WriteFile(pkgPath+".sig", []byte("fake signature bytes"), 0644))
+
+	var db bytes.Buffer
+	require.NoError(t, Default.BuildRepo([]string{pkgPath}, false, &db))
+
+	gr, err := gzip.NewReader(&db)
+	require.NoError(t, err)
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	var sigFound bool
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		if filepath.Base(hdr.Name) == "desc" {
+			bts, err := ioutil.ReadAll(tr)
+			require.NoError(t, err)
+			assert.Contains(t, string(bts), "%PGPSIG%\n"+base64.StdEncoding.EncodeToString([]byte("fake signature bytes")))
+			sigFound = true
+		}
+	}
+	assert.True(t, sigFound)
+}
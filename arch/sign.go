@@ -0,0 +1,32 @@
+package arch
+
+import (
+	"github.com/goreleaser/nfpm"
+	"github.com/goreleaser/nfpm/internal/sign"
+)
+
+// Signature produces a detached signature for the given package bytes,
+// suitable for publishing alongside the .pkg.tar.zst as a companion .sig
+// file so that `SigLevel = Required` pacman repos accept it. It defaults
+// to OpenPGP, pacman's only supported signature scheme.
+func Signature(info *nfpm.Info, pkg []byte) ([]byte, error) {
+	signerType := info.Pacman.Signature.Type
+	if signerType == "" {
+		signerType = "pgp"
+	}
+
+	signer, err := sign.Get(signerType)
+	if err != nil {
+		return nil, &nfpm.ErrSigningFailure{Err: err}
+	}
+
+	signature, err := signer.Sign(pkg, sign.SignOpts{
+		KeyFile:    info.Pacman.Signature.KeyFile,
+		KeyName:    info.Pacman.Signature.KeyName,
+		Passphrase: info.Pacman.Signature.KeyPassphrase,
+	})
+	if err != nil {
+		return nil, &nfpm.ErrSigningFailure{Err: err}
+	}
+	return signature, nil
+}
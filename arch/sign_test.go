@@ -0,0 +1,43 @@
+package arch
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/goreleaser/nfpm"
+	"github.com/goreleaser/nfpm/internal/sign"
+)
+
+func TestSignature(t *testing.T) {
+	info := exampleInfo()
+	info.Pacman.Signature.KeyFile = "testdata/privkey.asc"
+
+	var pkg bytes.Buffer
+	require.NoError(t, Default.Package(info, &pkg))
+
+	sig, err := Signature(info, pkg.Bytes())
+	require.NoError(t, err)
+
+	require.NoError(t, sign.VerifyDetachedSignature(pkg.Bytes(), sig, "testdata/pubkey.asc"))
+}
+
+func TestSignatureNoKeyFile(t *testing.T) {
+	info := exampleInfo()
+	info.Pacman.Signature.KeyFile = "testdata/doesnotexist.asc"
+
+	_, err := Signature(info, []byte("anything"))
+	require.Error(t, err)
+
+	var signingErr *nfpm.ErrSigningFailure
+	require.ErrorAs(t, err, &signingErr)
+}
+
+func TestSignatureUnknownType(t *testing.T) {
+	info := exampleInfo()
+	info.Pacman.Signature.Type = "doesnotexist"
+
+	_, err := Signature(info, []byte("anything"))
+	require.Error(t, err)
+}
@@ -0,0 +1,87 @@
+// Package cmd implements the `nfpm` CLI subcommands.
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goreleaser/nfpm"
+)
+
+// RepoOptions configures the `nfpm repo` subcommand.
+type RepoOptions struct {
+	// Format is the packager format whose RepoBuilder should be used,
+	// e.g. "arch".
+	Format string
+	// PackagesDir is the directory of already-built packages to index.
+	PackagesDir string
+	// RepoName is the name of the repository, used as the base name of
+	// the generated database files.
+	RepoName string
+}
+
+// Repo builds (or updates) a repository database out of the packages
+// found in opts.PackagesDir, writing "<RepoName>.db.tar.gz" and
+// "<RepoName>.files.tar.gz" into that same directory.
+func Repo(opts RepoOptions) error {
+	packager, err := nfpm.Get(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	builder, ok := packager.(nfpm.RepoBuilder)
+	if !ok {
+		return fmt.Errorf("packager %q does not support building repositories", opts.Format)
+	}
+
+	pkgPaths, err := packagesIn(opts.PackagesDir)
+	if err != nil {
+		return err
+	}
+
+	if err := writeRepoDB(builder, pkgPaths, false, filepath.Join(opts.PackagesDir, opts.RepoName+".db.tar.gz")); err != nil {
+		return err
+	}
+	return writeRepoDB(builder, pkgPaths, true, filepath.Join(opts.PackagesDir, opts.RepoName+".files.tar.gz"))
+}
+
+func packagesIn(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var pkgPaths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || isRepoDB(name) || isCompanionFile(name) {
+			continue
+		}
+		pkgPaths = append(pkgPaths, filepath.Join(dir, name))
+	}
+	return pkgPaths, nil
+}
+
+func isRepoDB(name string) bool {
+	return strings.HasSuffix(name, ".db.tar.gz") || strings.HasSuffix(name, ".files.tar.gz")
+}
+
+// isCompanionFile reports whether name is metadata written alongside a
+// package rather than a package itself, such as the detached ".sig"
+// signature a signer writes next to its package.
+func isCompanionFile(name string) bool {
+	return strings.HasSuffix(name, ".sig")
+}
+
+func writeRepoDB(builder nfpm.RepoBuilder, pkgPaths []string, withFiles bool, dst string) error {
+	f, err := os.Create(dst) // nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	defer f.Close() // nolint:errcheck
+
+	return builder.BuildRepo(pkgPaths, withFiles, f)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goreleaser/nfpm"
+	_ "github.com/goreleaser/nfpm/arch"
+)
+
+func TestRepo(t *testing.T) {
+	dir := t.TempDir()
+
+	packager, err := nfpm.Get("arch")
+	require.NoError(t, err)
+
+	info := nfpm.WithDefaults(&nfpm.Info{
+		Name:    "foo",
+		Arch:    "amd64",
+		Version: "1.0.0",
+	})
+
+	f, err := os.Create(filepath.Join(dir, packager.ConventionalFileName(info)))
+	require.NoError(t, err)
+	require.NoError(t, packager.Package(info, f))
+	require.NoError(t, f.Close())
+
+	require.NoError(t, Repo(RepoOptions{
+		Format:      "arch",
+		PackagesDir: dir,
+		RepoName:    "myrepo",
+	}))
+
+	db, err := os.Open(filepath.Join(dir, "myrepo.db.tar.gz"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	gr, err := gzip.NewReader(db)
+	require.NoError(t, err)
+	defer gr.Close()
+
+	var names []string
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+	assert.Contains(t, names, "foo-1.0.0-1/desc")
+}
+
+func TestRepoIgnoresSignatureCompanionFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	packager, err := nfpm.Get("arch")
+	require.NoError(t, err)
+
+	info := nfpm.WithDefaults(&nfpm.Info{
+		Name:    "foo",
+		Arch:    "amd64",
+		Version: "1.0.0",
+	})
+
+	pkgPath := filepath.Join(dir, packager.ConventionalFileName(info))
+	f, err := os.Create(pkgPath)
+	require.NoError(t, err)
+	require.NoError(t, packager.Package(info, f))
+	require.NoError(t, f.Close())
+
+	require.NoError(t, ioutil.WriteFile(pkgPath+".sig", []byte("fake signature bytes"), 0644))
+
+	require.NoError(t, Repo(RepoOptions{
+		Format:      "arch",
+		PackagesDir: dir,
+		RepoName:    "myrepo",
+	}))
+}
+
+func TestRepoUnknownFormat(t *testing.T) {
+	err := Repo(RepoOptions{Format: "doesnotexist", PackagesDir: t.TempDir(), RepoName: "myrepo"})
+	assert.Error(t, err)
+}
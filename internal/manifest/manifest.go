@@ -0,0 +1,153 @@
+// Package manifest walks the files a package is going to ship and
+// computes the metadata and checksums every format's control files need,
+// so that work only has to happen once regardless of how many of those
+// control files (md5sums, .MTREE, ...) a packager writes out.
+package manifest
+
+import (
+	"crypto/md5"  // nolint:gosec
+	"crypto/sha1" // nolint:gosec
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// FileEntry describes a single file, directory or symlink that will be
+// written into a package's payload.
+type FileEntry struct {
+	// Path is the destination path inside the package.
+	Path string
+	// Source is the file on disk that Path is built from. Empty for
+	// directories.
+	Source string
+	Mode   os.FileMode
+	UID    int
+	GID    int
+	Size   int64
+
+	ModTime time.Time
+
+	// LinkTarget is set when the entry is a symlink.
+	LinkTarget string
+
+	SHA256 []byte
+	SHA1   []byte
+	MD5    []byte
+}
+
+// IsDir reports whether the entry is a directory.
+func (e FileEntry) IsDir() bool { return e.Mode.IsDir() }
+
+// IsSymlink reports whether the entry is a symlink.
+func (e FileEntry) IsSymlink() bool { return e.LinkTarget != "" }
+
+// Walk walks files, configFiles and emptyFolders (in the shape of
+// nfpm.Info's fields of the same name) and returns one FileEntry per
+// planned payload entry, in that order.
+func Walk(files, configFiles map[string]string, emptyFolders []string) ([]FileEntry, error) {
+	var entries []FileEntry
+
+	add := func(globSrc, dst string) error {
+		matches, err := filepath.Glob(globSrc)
+		if err != nil || len(matches) == 0 {
+			return fmt.Errorf("glob failed: %s: file does not exist", globSrc)
+		}
+		for _, src := range matches {
+			if fi, err := os.Lstat(src); err == nil && fi.IsDir() {
+				continue
+			}
+			entry, err := newEntry(src, dst)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	}
+
+	for src, dst := range files {
+		if err := add(src, dst); err != nil {
+			return nil, err
+		}
+	}
+	for src, dst := range configFiles {
+		if err := add(src, dst); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range emptyFolders {
+		entries = append(entries, FileEntry{
+			Path:    path.Clean(dir),
+			Mode:    os.ModeDir | 0755,
+			ModTime: time.Now(),
+		})
+	}
+
+	return entries, nil
+}
+
+func newEntry(src, dst string) (FileEntry, error) {
+	fi, err := os.Lstat(src)
+	if err != nil {
+		return FileEntry{}, fmt.Errorf("%s: file does not exist", src)
+	}
+
+	uid, gid := ownerOf(fi)
+
+	if fi.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return FileEntry{}, fmt.Errorf("failed to read symlink %s: %w", src, err)
+		}
+		return FileEntry{
+			Path:       dst,
+			Source:     src,
+			Mode:       fi.Mode(),
+			UID:        uid,
+			GID:        gid,
+			ModTime:    fi.ModTime(),
+			LinkTarget: target,
+		}, nil
+	}
+
+	f, err := os.Open(src) // nolint:gosec
+	if err != nil {
+		return FileEntry{}, fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer f.Close() // nolint:errcheck
+
+	sha256sum := sha256.New()
+	sha1sum := sha1.New() // nolint:gosec
+	md5sum := md5.New()   // nolint:gosec
+	if _, err := io.Copy(io.MultiWriter(sha256sum, sha1sum, md5sum), f); err != nil {
+		return FileEntry{}, fmt.Errorf("failed to hash %s: %w", src, err)
+	}
+
+	return FileEntry{
+		Path:    dst,
+		Source:  src,
+		Mode:    fi.Mode(),
+		UID:     uid,
+		GID:     gid,
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+		SHA256:  sha256sum.Sum(nil),
+		SHA1:    sha1sum.Sum(nil),
+		MD5:     md5sum.Sum(nil),
+	}, nil
+}
+
+// ownerOf extracts the owning uid/gid from a os.FileInfo on platforms
+// that expose it via syscall.Stat_t, defaulting to root:root otherwise.
+func ownerOf(fi os.FileInfo) (uid, gid int) {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0
+	}
+	return int(stat.Uid), int(stat.Gid)
+}
@@ -0,0 +1,49 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalk(t *testing.T) {
+	files := map[string]string{
+		"../../testdata/fake": "/usr/local/bin/fake",
+	}
+	configFiles := map[string]string{
+		"../../testdata/whatever.conf": "/etc/fake/fake.conf",
+	}
+	emptyFolders := []string{
+		"/var/log/whatever",
+	}
+
+	entries, err := Walk(files, configFiles, emptyFolders)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	byPath := map[string]FileEntry{}
+	for _, e := range entries {
+		byPath[e.Path] = e
+	}
+
+	fake, ok := byPath["/usr/local/bin/fake"]
+	require.True(t, ok)
+	assert.NotEmpty(t, fake.SHA256)
+	assert.NotEmpty(t, fake.SHA1)
+	assert.NotEmpty(t, fake.MD5)
+	assert.False(t, fake.IsDir())
+
+	dir, ok := byPath["/var/log/whatever"]
+	require.True(t, ok)
+	assert.True(t, dir.IsDir())
+}
+
+func TestWalkMissingFile(t *testing.T) {
+	files := map[string]string{
+		"../../testdata/doesnotexist": "/usr/local/bin/fake",
+	}
+
+	_, err := Walk(files, nil, nil)
+	assert.EqualError(t, err, "glob failed: ../../testdata/doesnotexist: file does not exist")
+}
@@ -0,0 +1,57 @@
+package sign
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+)
+
+// GPGSigner shells out to the `gpg` binary to produce a detached
+// signature, for users whose key lives on a smartcard or YubiKey and
+// can't be loaded directly by the PGP signer.
+type GPGSigner struct{}
+
+// Name implements Signer.
+func (*GPGSigner) Name() string { return "gpg-agent" }
+
+// Sign implements Signer. opts.KeyFile is ignored; opts.KeyName, when
+// set, is passed to gpg as the --local-user to select which key to sign
+// with.
+func (*GPGSigner) Sign(digest []byte, opts SignOpts) ([]byte, error) {
+	tmp, err := ioutil.TempFile("", "nfpm-gpg-sign-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+	if _, err := tmp.Write(digest); err != nil {
+		tmp.Close() // nolint:errcheck,gosec
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	args := []string{"--batch", "--yes", "--detach-sign", "--output", "-"}
+	if opts.KeyName != "" {
+		args = append(args, "--local-user", opts.KeyName)
+	}
+	if opts.Passphrase != "" {
+		args = append(args, "--pinentry-mode", "loopback", "--passphrase-fd", "0")
+	}
+	args = append(args, tmp.Name())
+
+	cmd := exec.Command("gpg", args...) // nolint:gosec
+	if opts.Passphrase != "" {
+		cmd.Stdin = bytes.NewBufferString(opts.Passphrase)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("gpg: %w: %s", err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,86 @@
+package sign
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/openpgp" // nolint:staticcheck
+)
+
+// PGPSigner produces a binary OpenPGP detached signature using
+// golang.org/x/crypto/openpgp, reading KeyFile as either an armored or a
+// binary keyring.
+type PGPSigner struct{}
+
+// Name implements Signer.
+func (*PGPSigner) Name() string { return "pgp" }
+
+// Sign implements Signer.
+func (*PGPSigner) Sign(digest []byte, opts SignOpts) ([]byte, error) {
+	keyFile, err := os.Open(opts.KeyFile) // nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signing key: %w", err)
+	}
+	defer keyFile.Close() // nolint:errcheck
+
+	keyring, err := openpgp.ReadArmoredKeyRing(keyFile)
+	if err != nil {
+		if _, serr := keyFile.Seek(0, 0); serr != nil {
+			return nil, fmt.Errorf("failed to read signing key: %w", err)
+		}
+		keyring, err = openpgp.ReadKeyRing(keyFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	signer := selectEntity(keyring, opts.KeyName)
+	if signer == nil {
+		return nil, fmt.Errorf("no usable signing key found in %s", opts.KeyFile)
+	}
+	if opts.Passphrase != "" && signer.PrivateKey != nil && signer.PrivateKey.Encrypted {
+		if err := signer.PrivateKey.Decrypt([]byte(opts.Passphrase)); err != nil {
+			return nil, fmt.Errorf("failed to decrypt signing key: %w", err)
+		}
+	}
+
+	var sig bytes.Buffer
+	if err := openpgp.DetachSign(&sig, signer, bytes.NewReader(digest), nil); err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	return sig.Bytes(), nil
+}
+
+func selectEntity(keyring openpgp.EntityList, keyName string) *openpgp.Entity {
+	for _, entity := range keyring {
+		if keyName == "" {
+			return entity
+		}
+		for identity := range entity.Identities {
+			if identity == keyName {
+				return entity
+			}
+		}
+	}
+	return nil
+}
+
+// VerifyDetachedSignature checks that signature is a valid OpenPGP
+// signature of data made by a key in the armored keyring at
+// publicKeyFile.
+func VerifyDetachedSignature(data, signature []byte, publicKeyFile string) error {
+	keyFile, err := os.Open(publicKeyFile) // nolint:gosec
+	if err != nil {
+		return fmt.Errorf("failed to open public key: %w", err)
+	}
+	defer keyFile.Close() // nolint:errcheck
+
+	keyring, err := openpgp.ReadArmoredKeyRing(keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %w", err)
+	}
+
+	_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(data), bytes.NewReader(signature))
+	return err
+}
@@ -0,0 +1,34 @@
+package sign
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPGPSignAndVerify(t *testing.T) {
+	data := []byte("package contents go here")
+
+	signer, err := Get("pgp")
+	require.NoError(t, err)
+
+	sig, err := signer.Sign(data, SignOpts{
+		KeyFile: "testdata/pgp-private.asc",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, VerifyDetachedSignature(data, sig, "testdata/pgp-public.asc"))
+}
+
+func TestPGPVerifyRejectsTamperedData(t *testing.T) {
+	signer, err := Get("pgp")
+	require.NoError(t, err)
+
+	sig, err := signer.Sign([]byte("original contents"), SignOpts{
+		KeyFile: "testdata/pgp-private.asc",
+	})
+	require.NoError(t, err)
+
+	err = VerifyDetachedSignature([]byte("tampered contents"), sig, "testdata/pgp-public.asc")
+	require.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+)
+
+// RSASigner signs digests with a raw RSA private key, PKCS#1v1.5/SHA1,
+// the scheme used by apk's .SIGN.RSA.* entries.
+type RSASigner struct{}
+
+// Name implements Signer.
+func (*RSASigner) Name() string { return "rsa" }
+
+// Sign implements Signer.
+func (*RSASigner) Sign(digest []byte, opts SignOpts) ([]byte, error) {
+	return RSASignSHA1Digest(digest, opts.KeyFile, opts.Passphrase)
+}
+
+// RSASignSHA1Digest signs the given SHA1 digest with the RSA private key
+// found at privateKeyPath, decrypting it with passphrase if it is
+// encrypted.
+func RSASignSHA1Digest(digest []byte, privateKeyPath, passphrase string) ([]byte, error) {
+	key, err := loadPrivateKey(privateKeyPath, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, digest)
+}
+
+// RSAVerifySHA1Digest verifies that signature is a valid RSA signature of
+// digest, using the public key found at publicKeyPath.
+func RSAVerifySHA1Digest(digest, signature []byte, publicKeyPath string) error {
+	key, err := loadPublicKey(publicKeyPath)
+	if err != nil {
+		return err
+	}
+	return rsa.VerifyPKCS1v15(key, crypto.SHA1, digest, signature)
+}
+
+func loadPrivateKey(path, passphrase string) (*rsa.PrivateKey, error) {
+	bts, err := ioutil.ReadFile(path) // nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	block, _ := pem.Decode(bts)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block from %s", path)
+	}
+	der := block.Bytes
+	if x509.IsEncryptedPEMBlock(block) { // nolint:staticcheck
+		der, err = x509.DecryptPEMBlock(block, []byte(passphrase)) // nolint:staticcheck
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+		}
+	}
+	key, err := x509.ParsePKCS1PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return key, nil
+}
+
+func loadPublicKey(path string) (*rsa.PublicKey, error) {
+	bts, err := ioutil.ReadFile(path) // nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+	block, _ := pem.Decode(bts)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block from %s", path)
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	key, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s does not contain an RSA public key", path)
+	}
+	return key, nil
+}
@@ -0,0 +1,48 @@
+// Package sign provides the signing primitives used to sign package
+// digests, behind a pluggable Signer abstraction so each format can pick
+// raw RSA, OpenPGP or an external gpg binary.
+package sign
+
+import "fmt"
+
+// SignOpts carries everything a Signer needs to produce a signature.
+type SignOpts struct {
+	// KeyFile is the path to the signing key (private key, or armored
+	// secret keyring, depending on the Signer).
+	KeyFile string
+	// KeyName identifies which key/identity to use when KeyFile holds
+	// more than one, e.g. an apk key name or a PGP user ID.
+	KeyName string
+	// Passphrase decrypts KeyFile when it is encrypted.
+	Passphrase string
+}
+
+// Signer produces a detached signature over a digest.
+type Signer interface {
+	// Name identifies the signer, matching the Signature.Type value
+	// that selects it (e.g. "rsa", "pgp", "gpg-agent").
+	Name() string
+	// Sign returns a detached signature of digest.
+	Sign(digest []byte, opts SignOpts) ([]byte, error)
+}
+
+// nolint: gochecknoglobals
+var signers = map[string]Signer{
+	"rsa":       &RSASigner{},
+	"pgp":       &PGPSigner{},
+	"gpg-agent": &GPGSigner{},
+}
+
+// Get returns the Signer registered for the given Signature.Type. An
+// empty name defaults to "rsa" for backwards compatibility with formats
+// that only ever supported raw RSA signing.
+func Get(name string) (Signer, error) {
+	if name == "" {
+		name = "rsa"
+	}
+	signer, ok := signers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown signer %q", name)
+	}
+	return signer, nil
+}
@@ -0,0 +1,45 @@
+package sign
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	for _, name := range []string{"", "rsa", "pgp", "gpg-agent"} {
+		signer, err := Get(name)
+		require.NoError(t, err)
+		assert.NotNil(t, signer)
+	}
+
+	_, err := Get("doesnotexist")
+	assert.Error(t, err)
+}
+
+func TestRSASignAndVerify(t *testing.T) {
+	digest := []byte("01234567890123456789") // 20 bytes, SHA1-sized
+
+	signer, err := Get("rsa")
+	require.NoError(t, err)
+
+	sig, err := signer.Sign(digest, SignOpts{
+		KeyFile:    "testdata/rsa.priv",
+		Passphrase: "hunter2",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, RSAVerifySHA1Digest(digest, sig, "testdata/rsa.pub"))
+}
+
+func TestRSASignWrongPassphrase(t *testing.T) {
+	signer, err := Get("rsa")
+	require.NoError(t, err)
+
+	_, err = signer.Sign([]byte("digest-goes-here-000"), SignOpts{
+		KeyFile:    "testdata/rsa.priv",
+		Passphrase: "wrong",
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package nfpm_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/goreleaser/nfpm"
+	"github.com/goreleaser/nfpm/apk"
+	"github.com/goreleaser/nfpm/arch"
+)
+
+// TestManifestParity checks that every packager builds the same set of
+// destination paths and content checksums for the same Info, regardless
+// of format-specific quirks in how each one lays out its payload.
+func TestManifestParity(t *testing.T) {
+	info := nfpm.WithDefaults(&nfpm.Info{
+		Name:    "foo",
+		Arch:    "amd64",
+		Version: "1.0.0",
+		Overridables: nfpm.Overridables{
+			Files: map[string]string{
+				"testdata/fake": "/usr/local/bin/fake",
+			},
+			ConfigFiles: map[string]string{
+				"testdata/whatever.conf": "/etc/fake/fake.conf",
+			},
+			EmptyFolders: []string{
+				"/var/log/whatever",
+			},
+		},
+	})
+
+	apkManifest, err := apk.Default.Manifest(info)
+	require.NoError(t, err)
+
+	archManifest, err := arch.Default.Manifest(info)
+	require.NoError(t, err)
+
+	require.Len(t, archManifest, len(apkManifest))
+
+	byPath := map[string]nfpm.FileEntry{}
+	for _, entry := range apkManifest {
+		byPath[entry.Path] = entry
+	}
+
+	for _, entry := range archManifest {
+		other, ok := byPath[entry.Path]
+		assert.True(t, ok, "path %s present in arch manifest but not apk", entry.Path)
+		assert.Equal(t, other.Size, entry.Size, "size mismatch for %s", entry.Path)
+		assert.Equal(t, other.SHA256, entry.SHA256, "sha256 mismatch for %s", entry.Path)
+	}
+}
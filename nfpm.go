@@ -0,0 +1,382 @@
+// Package nfpm provides ways to package programs in some linux packaging
+// formats.
+package nfpm
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/goreleaser/nfpm/internal/manifest"
+)
+
+// FileEntry describes a single file, directory or symlink planned for a
+// package's payload. It is a type alias for internal/manifest.FileEntry
+// so that callers of Packager.Manifest don't need to import an internal
+// package themselves.
+type FileEntry = manifest.FileEntry
+
+// nolint: gochecknoglobals
+var packagers = map[string]Packager{}
+
+// Register a new packager for the given format.
+func Register(format string, packager Packager) {
+	packagers[format] = packager
+}
+
+// Get a packager for the given format.
+func Get(format string) (Packager, error) {
+	packager, ok := packagers[format]
+	if !ok {
+		return nil, fmt.Errorf("no packager registered for the format %s", format)
+	}
+	return packager, nil
+}
+
+// Parse decodes YAML data from an io.Reader into a configuration struct.
+func Parse(in io.Reader) (config Config, err error) {
+	bts, err := ioutil.ReadAll(in)
+	if err != nil {
+		return
+	}
+	bts = []byte(os.Expand(string(bts), expand))
+	err = yaml.Unmarshal(bts, &config)
+	if err != nil {
+		return
+	}
+	for format := range config.Overrides {
+		if _, err = Get(format); err != nil {
+			return Config{}, fmt.Errorf("override for unknown packager %q: %w", format, err)
+		}
+	}
+	applyPassphrases(&config.Info)
+	return config, nil
+}
+
+// applyPassphrases fills in the per-format signing passphrase from the
+// environment, falling back to the global NFPM_PASSPHRASE when a
+// format-specific one isn't set.
+func applyPassphrases(info *Info) {
+	global := os.Getenv("NFPM_PASSPHRASE")
+	info.Deb.Signature.KeyPassphrase = firstNonEmpty(os.Getenv("NFPM_DEB_PASSPHRASE"), global)
+	info.RPM.Signature.KeyPassphrase = firstNonEmpty(os.Getenv("NFPM_RPM_PASSPHRASE"), global)
+	info.APK.Signature.KeyPassphrase = firstNonEmpty(os.Getenv("NFPM_APK_PASSPHRASE"), global)
+	info.Pacman.Signature.KeyPassphrase = firstNonEmpty(os.Getenv("NFPM_PACMAN_PASSPHRASE"), global)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// expand replaces each environment variable reference with its value;
+// variables that are not defined expand to the empty string.
+func expand(key string) string {
+	return os.Getenv(key)
+}
+
+// ParseFile decodes YAML data from a file path into a configuration struct.
+func ParseFile(path string) (config Config, err error) {
+	f, err := os.Open(path) // nolint:gosec
+	if err != nil {
+		return
+	}
+	defer f.Close() // nolint:errcheck
+	return Parse(f)
+}
+
+// Config is the top level configuration structure.
+type Config struct {
+	Info      `yaml:",inline"`
+	Overrides map[string]Overridables `yaml:"overrides,omitempty"`
+}
+
+// Get returns the Info struct for the given packager, merging in any
+// overrides defined for that format. If no override is defined, the base
+// Info is returned unchanged.
+func (c *Config) Get(format string) (info *Info, err error) {
+	info = &Info{}
+	*info = c.Info
+	override, ok := c.Overrides[format]
+	if !ok {
+		return info, nil
+	}
+	info.Overridables = mergeOverrides(info.Overridables, override)
+	return info, nil
+}
+
+func mergeOverrides(base, override Overridables) Overridables {
+	if len(override.Depends) > 0 {
+		base.Depends = override.Depends
+	}
+	if len(override.Recommends) > 0 {
+		base.Recommends = override.Recommends
+	}
+	if len(override.Suggests) > 0 {
+		base.Suggests = override.Suggests
+	}
+	if len(override.Replaces) > 0 {
+		base.Replaces = override.Replaces
+	}
+	if len(override.Provides) > 0 {
+		base.Provides = override.Provides
+	}
+	if len(override.Conflicts) > 0 {
+		base.Conflicts = override.Conflicts
+	}
+	if len(override.Files) > 0 {
+		base.Files = override.Files
+	}
+	if override.ConfigFiles != nil {
+		for k, v := range override.ConfigFiles {
+			if base.ConfigFiles == nil {
+				base.ConfigFiles = map[string]string{}
+			}
+			base.ConfigFiles[k] = v
+		}
+	}
+	if len(override.EmptyFolders) > 0 {
+		base.EmptyFolders = override.EmptyFolders
+	}
+	if len(override.OptDepends) > 0 {
+		base.OptDepends = override.OptDepends
+	}
+	return base
+}
+
+// WithDefaults fills in sane defaults for fields that were not provided.
+func WithDefaults(info *Info) *Info {
+	if info.Platform == "" {
+		info.Platform = "linux"
+	}
+	if info.Description == "" {
+		info.Description = "no description given"
+	}
+	info.Version, info.Release, info.Prerelease = parseVersion(info.Version, info.Release, info.Prerelease)
+	return info
+}
+
+func parseVersion(version, release, prerelease string) (string, string, string) {
+	version = strings.TrimPrefix(version, "v")
+	parts := strings.SplitN(version, "-", 2)
+	v := parts[0]
+	if len(parts) > 1 && prerelease == "" {
+		prerelease = parts[1]
+	}
+	return v, release, prerelease
+}
+
+// Validate the given Info and returns an error if it is invalid.
+func Validate(info *Info) (err error) {
+	if info.Name == "" {
+		return fmt.Errorf("package name must be provided")
+	}
+	if info.Arch == "" {
+		return fmt.Errorf("package arch must be provided")
+	}
+	if info.Version == "" {
+		return fmt.Errorf("package version must be provided")
+	}
+	return nil
+}
+
+// Packager represents any packager implementation.
+type Packager interface {
+	Package(info *Info, w io.Writer) error
+	ConventionalFileName(info *Info) string
+	// Manifest returns the list of files, directories and symlinks that
+	// Package would write for info, without actually building a
+	// package. It lets downstream tooling (SBOM generators, policy
+	// checkers, ...) introspect planned contents up front.
+	Manifest(info *Info) ([]FileEntry, error)
+}
+
+// RepoBuilder is implemented by packagers that can assemble a set of
+// already-built packages into a repository index, such as pacman's
+// repo-add. Callers should use a type assertion against a Packager
+// obtained from Get to discover support for it.
+type RepoBuilder interface {
+	// BuildRepo writes a repository database containing one entry per
+	// package found in pkgPaths to w. withFiles additionally includes
+	// every payload path in each entry, for repo-add's ".files" variant.
+	BuildRepo(pkgPaths []string, withFiles bool, w io.Writer) error
+}
+
+// Scripts contains the scripts that will be executed at certain points of
+// the package's lifecycle.
+type Scripts struct {
+	PreInstall  string `yaml:"preinstall,omitempty"`
+	PostInstall string `yaml:"postinstall,omitempty"`
+	PreRemove   string `yaml:"preremove,omitempty"`
+	PostRemove  string `yaml:"postremove,omitempty"`
+}
+
+// Info contains information about a single package.
+type Info struct {
+	Platform     string  `yaml:"platform,omitempty"`
+	Name         string  `yaml:"name,omitempty"`
+	Arch         string  `yaml:"arch,omitempty"`
+	Version      string  `yaml:"version,omitempty"`
+	Release      string  `yaml:"release,omitempty"`
+	Prerelease   string  `yaml:"prerelease,omitempty"`
+	Section      string  `yaml:"section,omitempty"`
+	Priority     string  `yaml:"priority,omitempty"`
+	Maintainer   string  `yaml:"maintainer,omitempty"`
+	Description  string  `yaml:"description,omitempty"`
+	Vendor       string  `yaml:"vendor,omitempty"`
+	Homepage     string  `yaml:"homepage,omitempty"`
+	License      string  `yaml:"license,omitempty"`
+	Bindir       string  `yaml:"bindir,omitempty"`
+	Scripts      Scripts `yaml:"scripts,omitempty"`
+	Overridables `yaml:",inline"`
+
+	Deb    DebPackage  `yaml:"deb,omitempty"`
+	RPM    RPMPackage  `yaml:"rpm,omitempty"`
+	APK    APKPackage  `yaml:"apk,omitempty"`
+	Pacman ArchPackage `yaml:"pacman,omitempty"`
+}
+
+// Overridables contains the fields which may be overridden for a given
+// packager.
+type Overridables struct {
+	Replaces     []string          `yaml:"replaces,omitempty"`
+	Provides     []string          `yaml:"provides,omitempty"`
+	Depends      []string          `yaml:"depends,omitempty"`
+	Recommends   []string          `yaml:"recommends,omitempty"`
+	Suggests     []string          `yaml:"suggests,omitempty"`
+	Conflicts    []string          `yaml:"conflicts,omitempty"`
+	Files        map[string]string `yaml:"files,omitempty"`
+	ConfigFiles  map[string]string `yaml:"config_files,omitempty"`
+	EmptyFolders []string          `yaml:"empty_folders,omitempty"`
+	OptDepends   []OptDependency   `yaml:"optdepends,omitempty"`
+}
+
+// OptDependency represents an optional, suggested or recommended
+// dependency, together with the reason it's suggested. It unmarshals from
+// either a plain string ("foo" or "foo>=1.0") or a single-key mapping
+// ("foo>=1.0: enables bar functionality").
+type OptDependency struct {
+	Name    string
+	Version string
+	Reason  string
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, accepting either form
+// documented on OptDependency.
+func (d *OptDependency) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var spec string
+	if err := unmarshal(&spec); err == nil {
+		name, version, err := parseDependencySpec(spec)
+		if err != nil {
+			return err
+		}
+		d.Name, d.Version, d.Reason = name, version, ""
+		return nil
+	}
+
+	var mapping map[string]string
+	if err := unmarshal(&mapping); err != nil {
+		return fmt.Errorf("optdepend must be a string or a single-key mapping: %w", err)
+	}
+	if len(mapping) != 1 {
+		return fmt.Errorf("optdepend mapping must have exactly one key, got %d", len(mapping))
+	}
+	for spec, reason := range mapping {
+		name, version, err := parseDependencySpec(spec)
+		if err != nil {
+			return err
+		}
+		d.Name, d.Version, d.Reason = name, version, reason
+	}
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler, emitting the plain string form
+// ("name" or "name>=1.0") when there's no Reason, and the single-key
+// mapping form ("name>=1.0: reason") otherwise, so a parsed OptDependency
+// round-trips back to whichever form it was read from.
+func (d OptDependency) MarshalYAML() (interface{}, error) {
+	spec := d.Name + d.Version
+	if d.Reason == "" {
+		return spec, nil
+	}
+	return map[string]string{spec: d.Reason}, nil
+}
+
+// nolint: gochecknoglobals
+var dependencyOperators = []string{">=", "<=", "==", "!=", ">", "<", "="}
+
+// parseDependencySpec splits a dependency spec such as "foo>=1.0" into its
+// name and version constraint, returning an error if an operator is
+// present but no version follows it.
+func parseDependencySpec(spec string) (name, version string, err error) {
+	for _, op := range dependencyOperators {
+		idx := strings.Index(spec, op)
+		if idx < 0 {
+			continue
+		}
+		name = spec[:idx]
+		version = spec[idx:]
+		if strings.TrimPrefix(version, op) == "" {
+			return "", "", fmt.Errorf("malformed version constraint in %q", spec)
+		}
+		return name, version, nil
+	}
+	return spec, "", nil
+}
+
+// Signature holds the configuration needed to sign a package.
+type Signature struct {
+	KeyFile string `yaml:"key_file,omitempty"`
+	KeyName string `yaml:"key_name,omitempty"`
+	// Type selects the internal/sign.Signer used to produce the
+	// signature: "rsa" (raw RSA/PKCS#1v1.5), "pgp"
+	// (golang.org/x/crypto/openpgp) or "gpg-agent" (shells out to the
+	// gpg binary, for smartcard/YubiKey-backed keys). When empty, it
+	// defaults to "rsa", except for pacman packages, where it defaults
+	// to "pgp".
+	Type          string `yaml:"type,omitempty"`
+	KeyPassphrase string `yaml:"-"`
+}
+
+// DebPackage is custom configuration for deb packages.
+type DebPackage struct {
+	VersionMetadata string    `yaml:"version_metadata,omitempty"`
+	Signature       Signature `yaml:"signature,omitempty"`
+}
+
+// RPMPackage is custom configuration for rpm packages.
+type RPMPackage struct {
+	Signature Signature `yaml:"signature,omitempty"`
+}
+
+// APKPackage is custom configuration for apk packages.
+type APKPackage struct {
+	Signature Signature `yaml:"signature,omitempty"`
+}
+
+// ArchPackage is custom configuration for Arch Linux (pacman) packages.
+type ArchPackage struct {
+	Signature Signature `yaml:"signature,omitempty"`
+}
+
+// ErrSigningFailure happens when the signing of a package fails.
+type ErrSigningFailure struct {
+	Err error
+}
+
+func (e *ErrSigningFailure) Error() string {
+	return fmt.Sprintf("signing failure: %v", e.Err)
+}
+
+func (e *ErrSigningFailure) Unwrap() error {
+	return e.Err
+}
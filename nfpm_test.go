@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
 )
 
 func TestRegister(t *testing.T) {
@@ -156,6 +157,7 @@ func TestOptionsFromEnvironment(t *testing.T) {
 		debPass    = "password123"
 		rpmPass    = "secret"
 		apkPass    = "foobar"
+		pacmanPass = "swordfish"
 		release    = "3"
 		version    = "1.0.0"
 	)
@@ -184,6 +186,7 @@ func TestOptionsFromEnvironment(t *testing.T) {
 		assert.Equal(t, globalPass, info.Deb.Signature.KeyPassphrase)
 		assert.Equal(t, globalPass, info.RPM.Signature.KeyPassphrase)
 		assert.Equal(t, globalPass, info.APK.Signature.KeyPassphrase)
+		assert.Equal(t, globalPass, info.Pacman.Signature.KeyPassphrase)
 	})
 
 	t.Run("specific passphrases", func(t *testing.T) {
@@ -192,11 +195,13 @@ func TestOptionsFromEnvironment(t *testing.T) {
 		os.Setenv("NFPM_DEB_PASSPHRASE", debPass)
 		os.Setenv("NFPM_RPM_PASSPHRASE", rpmPass)
 		os.Setenv("NFPM_APK_PASSPHRASE", apkPass)
+		os.Setenv("NFPM_PACMAN_PASSPHRASE", pacmanPass)
 		info, err := Parse(strings.NewReader("name: foo"))
 		require.NoError(t, err)
 		assert.Equal(t, debPass, info.Deb.Signature.KeyPassphrase)
 		assert.Equal(t, rpmPass, info.RPM.Signature.KeyPassphrase)
 		assert.Equal(t, apkPass, info.APK.Signature.KeyPassphrase)
+		assert.Equal(t, pacmanPass, info.Pacman.Signature.KeyPassphrase)
 	})
 }
 
@@ -233,6 +238,64 @@ func TestOverrides(t *testing.T) {
 	assert.True(t, reflect.DeepEqual(&config.Info, info))
 }
 
+func TestOptDependsUnmarshal(t *testing.T) {
+	info, err := Parse(strings.NewReader(`
+name: foo
+optdepends:
+  - bar
+  - baz>=1.0
+  - qux: enables qux support
+  - quux>=2.0: enables quux support
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []OptDependency{
+		{Name: "bar"},
+		{Name: "baz", Version: ">=1.0"},
+		{Name: "qux", Reason: "enables qux support"},
+		{Name: "quux", Version: ">=2.0", Reason: "enables quux support"},
+	}, info.OptDepends)
+}
+
+func TestOptDependsMalformedVersion(t *testing.T) {
+	_, err := Parse(strings.NewReader(`
+name: foo
+optdepends:
+  - bar>=
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "malformed version constraint")
+}
+
+func TestOptDependsBadMapping(t *testing.T) {
+	_, err := Parse(strings.NewReader(`
+name: foo
+optdepends:
+  - bar: one
+    baz: two
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exactly one key")
+}
+
+func TestOptDependsMarshalRoundTrip(t *testing.T) {
+	info, err := Parse(strings.NewReader(`
+name: foo
+optdepends:
+  - bar
+  - baz>=1.0
+  - qux: enables qux support
+  - quux>=2.0: enables quux support
+`))
+	require.NoError(t, err)
+
+	out, err := yaml.Marshal(info.OptDepends)
+	require.NoError(t, err)
+
+	var roundTripped []OptDependency
+	require.NoError(t, yaml.Unmarshal(out, &roundTripped))
+	assert.Equal(t, info.OptDepends, roundTripped)
+}
+
 type fakePackager struct{}
 
 func (*fakePackager) ConventionalFileName(info *Info) string {
@@ -242,3 +305,7 @@ func (*fakePackager) ConventionalFileName(info *Info) string {
 func (*fakePackager) Package(info *Info, w io.Writer) error {
 	return nil
 }
+
+func (*fakePackager) Manifest(info *Info) ([]FileEntry, error) {
+	return nil, nil
+}